@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	iopath "path"
+	"path/filepath"
+	"sync"
+
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/afero"
+)
+
+const (
+	// keyClusterName is the key under which a cluster's name is stored.
+	keyClusterName = "cluster.name"
+
+	// attachedFilename is the name of the file used to mark a cluster as attached.
+	attachedFilename = "attached"
+)
+
+// Opts are functional options for a Config.
+type Opts struct {
+	// Fs is an abstraction for the filesystem. All filesystem operations
+	// for the config should be done through it instead of the os package.
+	Fs afero.Fs
+
+	// ReadFS, when set, is used instead of Fs to read config files (LoadPath
+	// and overlay merging). Fs is still used for writes (Persist).
+	ReadFS fs.FS
+
+	// EnvLookup is the function used to lookup environment variables.
+	// When not set it defaults to os.LookupEnv.
+	EnvLookup func(key string) (string, bool)
+
+	// Formats are the ConfigFormats recognized when loading config files.
+	// When not set it defaults to TOML, YAML, and JSON.
+	Formats []ConfigFormat
+}
+
+// Config for the CLI.
+type Config struct {
+	sync.Mutex
+
+	tree *toml.Tree
+	path string
+
+	// layers records the source files merged into tree, from least-specific
+	// to most-specific. The base config (see Path) is always the first entry.
+	layers []string
+
+	fs        afero.Fs
+	readFS    fs.FS
+	envLookup func(key string) (string, bool)
+	formats   []ConfigFormat
+}
+
+// New creates a config.
+func New(opts Opts) *Config {
+	if opts.Fs == nil {
+		opts.Fs = afero.NewOsFs()
+	}
+	if opts.EnvLookup == nil {
+		opts.EnvLookup = os.LookupEnv
+	}
+	if opts.Formats == nil {
+		opts.Formats = defaultFormats()
+	}
+	return &Config{
+		tree:      emptyTree(),
+		fs:        opts.Fs,
+		readFS:    opts.ReadFS,
+		envLookup: opts.EnvLookup,
+		formats:   opts.Formats,
+	}
+}
+
+// emptyTree returns an empty TOML tree.
+func emptyTree() *toml.Tree {
+	tree, _ := toml.Load("")
+	return tree
+}
+
+// Path returns the path of the config.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// LoadPath loads the config located at a given path. The format used to
+// decode it is picked from its file extension, among the Config's
+// registered ConfigFormats.
+func (c *Config) LoadPath(path string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.path = path
+
+	format := formatForExt(c.formats, ext(path))
+	if format == nil {
+		return fmt.Errorf("config: unsupported file extension %q", filepath.Ext(path))
+	}
+
+	data, err := c.readFile(path)
+	if err != nil {
+		return err
+	}
+
+	m, err := format.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	tree, err := toml.TreeFromMap(m)
+	if err != nil {
+		return err
+	}
+	c.tree = tree
+	c.layers = []string{path}
+	return nil
+}
+
+// readFile reads path through readFS when set, or through the afero Fs
+// otherwise. Write operations (Persist) always go through the afero Fs.
+func (c *Config) readFile(path string) ([]byte, error) {
+	if c.readFS != nil {
+		return fs.ReadFile(c.readFS, path)
+	}
+	return afero.ReadFile(c.fs, path)
+}
+
+// LoadBytes loads the config from raw data, decoded using the ConfigFormat
+// registered for formatExt (e.g. "toml"). Unlike LoadPath, it doesn't set
+// Path or Layers, since data isn't coming from a file.
+func (c *Config) LoadBytes(data []byte, formatExt string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	format := formatForExt(c.formats, formatExt)
+	if format == nil {
+		return fmt.Errorf("config: unsupported format %q", formatExt)
+	}
+
+	m, err := format.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	tree, err := toml.TreeFromMap(m)
+	if err != nil {
+		return err
+	}
+	c.tree = tree
+	return nil
+}
+
+// Layers returns the ordered list of source files merged into this config,
+// from least-specific to most-specific. It is empty until LoadPath succeeds.
+func (c *Config) Layers() []string {
+	c.Lock()
+	defer c.Unlock()
+	return append([]string(nil), c.layers...)
+}
+
+// Get gets a value for a given key.
+func (c *Config) Get(key string) interface{} {
+	c.Lock()
+	defer c.Unlock()
+	return c.tree.Get(key)
+}
+
+// Set sets a value for a given key. If value is a table and key already
+// holds one, they are combined following the same rules as overlay loading
+// (see Merge): a "_merge" key within value controls whether it deep-merges,
+// shallow-merges, or replaces the existing table, defaulting to deep. Any
+// other value (scalar or array) replaces the key outright.
+func (c *Config) Set(key string, value interface{}) {
+	c.Lock()
+	defer c.Unlock()
+
+	srcMap, isMap := value.(map[string]interface{})
+	if !isMap {
+		c.tree.Set(key, value)
+		return
+	}
+
+	var dstMap map[string]interface{}
+	if existing, ok := c.tree.Get(key).(*toml.Tree); ok {
+		dstMap = existing.ToMap()
+	}
+
+	c.tree.Set(key, Merge(dstMap, srcMap, StrategyDeep))
+}
+
+// Attached returns whether or not the cluster for this config is attached.
+func (c *Config) Attached() bool {
+	if c.readFS != nil {
+		attachedPath := iopath.Join(iopath.Dir(c.path), attachedFilename)
+		_, err := fs.Stat(c.readFS, attachedPath)
+		return err == nil
+	}
+	attachedPath := filepath.Join(filepath.Dir(c.path), attachedFilename)
+	_, err := c.fs.Stat(attachedPath)
+	return err == nil
+}
+
+// Persist saves the config to disk at its current path.
+func (c *Config) Persist() error {
+	c.Lock()
+	defer c.Unlock()
+
+	data, err := c.tree.ToTomlString()
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(c.fs, c.path, []byte(data), 0600)
+}
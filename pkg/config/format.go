@@ -0,0 +1,138 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigFormat decodes and encodes a config source, for a given set of file
+// extensions, into the generic map representation used internally by Config.
+type ConfigFormat interface {
+	// Extensions returns the file extensions handled by this format, without
+	// the leading dot (e.g. "toml", "yaml").
+	Extensions() []string
+
+	// Decode parses data into a generic map.
+	Decode(data []byte) (map[string]interface{}, error)
+
+	// Encode serializes a generic map.
+	Encode(m map[string]interface{}) ([]byte, error)
+}
+
+// defaultFormats returns the config formats supported out of the box.
+func defaultFormats() []ConfigFormat {
+	return []ConfigFormat{tomlFormat{}, yamlFormat{}, jsonFormat{}}
+}
+
+// ext returns the extension of path, without its leading dot.
+func ext(path string) string {
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
+
+// formatForExt returns the format within formats handling a given extension,
+// or nil if none of them do.
+func formatForExt(formats []ConfigFormat, extension string) ConfigFormat {
+	for _, format := range formats {
+		for _, e := range format.Extensions() {
+			if e == extension {
+				return format
+			}
+		}
+	}
+	return nil
+}
+
+// tomlFormat is the built-in TOML ConfigFormat.
+type tomlFormat struct{}
+
+func (tomlFormat) Extensions() []string { return []string{"toml"} }
+
+func (tomlFormat) Decode(data []byte) (map[string]interface{}, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return tree.ToMap(), nil
+}
+
+func (tomlFormat) Encode(m map[string]interface{}) ([]byte, error) {
+	tree, err := toml.TreeFromMap(m)
+	if err != nil {
+		return nil, err
+	}
+	s, err := tree.ToTomlString()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// yamlFormat is the built-in YAML ConfigFormat.
+type yamlFormat struct{}
+
+func (yamlFormat) Extensions() []string { return []string{"yaml", "yml"} }
+
+func (yamlFormat) Decode(data []byte) (map[string]interface{}, error) {
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	m, _ := normalizeYAML(raw).(map[string]interface{})
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m, nil
+}
+
+func (yamlFormat) Encode(m map[string]interface{}) ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// normalizeYAML converts the map[interface{}]interface{} values produced by
+// gopkg.in/yaml.v2 into map[string]interface{}, recursively, so that they
+// behave like the output of encoding/json and go-toml's ToMap.
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[toString(k)] = normalizeYAML(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalizeYAML(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// jsonFormat is the built-in JSON ConfigFormat.
+type jsonFormat struct{}
+
+func (jsonFormat) Extensions() []string { return []string{"json"} }
+
+func (jsonFormat) Decode(data []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (jsonFormat) Encode(m map[string]interface{}) ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
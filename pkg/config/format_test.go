@@ -0,0 +1,140 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestExt(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"dcos.toml", "toml"},
+		{"/a/b/dcos.yaml", "yaml"},
+		{"dcos", ""},
+		{"archive.tar.gz", "gz"},
+	}
+	for _, tt := range tests {
+		if got := ext(tt.path); got != tt.want {
+			t.Errorf("ext(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFormatForExt(t *testing.T) {
+	formats := defaultFormats()
+
+	tests := []struct {
+		extension string
+		wantNil   bool
+	}{
+		{"toml", false},
+		{"yaml", false},
+		{"yml", false},
+		{"json", false},
+		{"ini", true},
+	}
+	for _, tt := range tests {
+		format := formatForExt(formats, tt.extension)
+		if tt.wantNil && format != nil {
+			t.Errorf("formatForExt(%q) = %#v, want nil", tt.extension, format)
+		}
+		if !tt.wantNil && format == nil {
+			t.Errorf("formatForExt(%q) = nil, want a format", tt.extension)
+		}
+	}
+}
+
+// overrideJSONFormat shares the "json" extension with the built-in jsonFormat.
+type overrideJSONFormat struct{ jsonFormat }
+
+func TestFormatForExtPrefersMostRecentlyRegistered(t *testing.T) {
+	m, err := NewManager(ManagerOpts{Fs: afero.NewMemMapFs(), EnvLookup: func(string) (string, bool) { return "", false }})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.RegisterFormat(overrideJSONFormat{})
+	if _, ok := formatForExt(m.formats, "json").(overrideJSONFormat); !ok {
+		t.Fatalf("formatForExt(%q) didn't return the most recently registered format", "json")
+	}
+}
+
+func TestYAMLFormatDecodeNormalizesNestedMaps(t *testing.T) {
+	data := []byte("core:\n  dcos_url: https://example.com\n  timeout: 5\ncluster:\n  name: test\n")
+
+	m, err := (yamlFormat{}).Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"core": map[string]interface{}{
+			"dcos_url": "https://example.com",
+			"timeout":  5,
+		},
+		"cluster": map[string]interface{}{"name": "test"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Decode() = %#v, want %#v", m, want)
+	}
+}
+
+func TestJSONFormatRoundTrip(t *testing.T) {
+	m := map[string]interface{}{
+		"core": map[string]interface{}{"timeout": float64(5)},
+	}
+
+	data, err := (jsonFormat{}).Encode(m)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	got, err := (jsonFormat{}).Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("round trip = %#v, want %#v", got, m)
+	}
+}
+
+func TestManagerAllSkipsAmbiguousClusters(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `cluster.name = "toml-cluster"`)
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.yaml", "cluster:\n  name: yaml-cluster\n")
+	writeFile(t, fs, "/dcos/clusters/def/dcos.toml", `cluster.name = "only-one"`)
+
+	m, err := NewManager(ManagerOpts{Fs: fs, Dir: "/dcos", EnvLookup: func(string) (string, bool) { return "", false }})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	configs := m.All()
+	if len(configs) != 1 {
+		t.Fatalf("All() returned %d configs, want 1 (ambiguous cluster should be skipped)", len(configs))
+	}
+	if got := configs[0].Get(keyClusterName); got != "only-one" {
+		t.Errorf("cluster.name = %#v, want %q", got, "only-one")
+	}
+}
+
+func TestManagerRegisterFormatAvoidsFalseAmbiguity(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `cluster.name = "test"`)
+
+	m, err := NewManager(ManagerOpts{Fs: fs, Dir: "/dcos", EnvLookup: func(string) (string, bool) { return "", false }})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	// Registering another format for an extension already recognized
+	// shouldn't make a single matching file look ambiguous.
+	m.RegisterFormat(tomlFormat{})
+
+	configs := m.All()
+	if len(configs) != 1 {
+		t.Fatalf("All() returned %d configs, want 1", len(configs))
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+// Logger is the minimal logging interface used by a Loader to surface
+// warnings encountered while loading a config.
+type Logger interface {
+	Warn(args ...interface{})
+}
+
+// deprecatedKeys maps a deprecated config key to a message describing its
+// replacement. It is empty until a key actually needs to be deprecated.
+var deprecatedKeys = map[string]string{}
+
+// Loader resolves and loads a Config from a set of candidate sources. It
+// supersedes the lookup chain that used to be hardcoded in Manager.Current,
+// exposing it as flags so every command resolves configs the same way.
+type Loader struct {
+	// Path, when set, points directly at a config file to load (the
+	// equivalent of the DCOS_CONFIG env var). A Path of "-" reads TOML
+	// from Stdin instead.
+	Path string
+
+	// ClusterName, when set, selects a configured cluster by name or ID
+	// (the equivalent of the DCOS_CLUSTER env var).
+	ClusterName string
+
+	// LegacyPath is the config file loaded when no cluster is configured
+	// at all. Defaults to "<dir>/dcos.toml".
+	LegacyPath string
+
+	// Stdin is read from when Path is "-". Defaults to os.Stdin.
+	Stdin io.Reader
+
+	// SkipLegacy disables falling back to LegacyPath.
+	SkipLegacy bool
+
+	// SkipDeprecated disables warnings for deprecated config keys.
+	SkipDeprecated bool
+
+	// Logger receives warnings as they are encountered, in addition to
+	// them being returned by Load. Optional.
+	Logger Logger
+
+	manager *Manager
+}
+
+// NewLoader creates a Loader resolving configs through manager.
+func NewLoader(manager *Manager) *Loader {
+	return &Loader{
+		LegacyPath: manager.join(manager.dir, "dcos.toml"),
+		manager:    manager,
+	}
+}
+
+// SetupFlags binds the Loader's fields to --config, --cluster, and
+// --no-legacy flags, so that `dcos` subcommands get consistent config
+// resolution flags for free.
+func (l *Loader) SetupFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&l.Path, "config", l.Path, "Path to a config file, - for stdin")
+	flags.StringVar(&l.ClusterName, "cluster", l.ClusterName, "Name or ID of the cluster to use")
+	flags.BoolVar(&l.SkipLegacy, "no-legacy", l.SkipLegacy, "Don't fall back to the legacy config file")
+}
+
+// Load resolves and loads a Config, following (in order): Path, ClusterName,
+// the attached/only configured cluster, and finally LegacyPath unless
+// SkipLegacy is set. It also returns warnings for any deprecated keys found
+// in the resulting config, unless SkipDeprecated is set.
+func (l *Loader) Load() (*Config, []string, error) {
+	switch {
+	case l.Path == "-":
+		data, err := ioutil.ReadAll(l.stdin())
+		if err != nil {
+			return nil, nil, err
+		}
+		config := l.manager.newConfig()
+		if err := config.LoadBytes(data, "toml"); err != nil {
+			return nil, nil, err
+		}
+		return config, l.deprecationWarnings(config), nil
+
+	case l.Path != "":
+		config := l.manager.newConfig()
+		if err := config.LoadPath(l.Path); err != nil {
+			return nil, nil, err
+		}
+		return config, l.deprecationWarnings(config), nil
+
+	case l.ClusterName != "":
+		config, err := l.manager.Find(l.ClusterName, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		return config, l.deprecationWarnings(config), nil
+	}
+
+	configs := l.manager.All()
+	switch len(configs) {
+	case 0:
+		if l.SkipLegacy {
+			return nil, nil, errors.New("no cluster is configured")
+		}
+		config := l.manager.newConfig()
+		if err := config.LoadPath(l.LegacyPath); err != nil {
+			return nil, nil, err
+		}
+		return config, l.deprecationWarnings(config), nil
+
+	case 1:
+		return configs[0], l.deprecationWarnings(configs[0]), nil
+
+	default:
+		var current *Config
+		for _, config := range configs {
+			if config.Attached() {
+				if current != nil {
+					return nil, nil, errors.New("multiple clusters are attached")
+				}
+				current = config
+			}
+		}
+		if current == nil {
+			return nil, nil, errors.New("no cluster is attached")
+		}
+		return current, l.deprecationWarnings(current), nil
+	}
+}
+
+func (l *Loader) stdin() io.Reader {
+	if l.Stdin != nil {
+		return l.Stdin
+	}
+	return os.Stdin
+}
+
+// deprecationWarnings returns a warning for every deprecated key present in
+// config, logging each one through l.Logger if set.
+func (l *Loader) deprecationWarnings(config *Config) []string {
+	if l.SkipDeprecated {
+		return nil
+	}
+
+	var warnings []string
+	for key, msg := range deprecatedKeys {
+		if config.Get(key) == nil {
+			continue
+		}
+		warning := fmt.Sprintf("%q is deprecated: %s", key, msg)
+		warnings = append(warnings, warning)
+		if l.Logger != nil {
+			l.Logger.Warn(warning)
+		}
+	}
+	return warnings
+}
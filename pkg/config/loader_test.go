@@ -0,0 +1,195 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func newTestManager(t *testing.T, fs afero.Fs) *Manager {
+	t.Helper()
+	m, err := NewManager(ManagerOpts{Fs: fs, Dir: "/dcos", EnvLookup: func(string) (string, bool) { return "", false }})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+func TestLoaderLoadExplicitPath(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/somewhere/dcos.toml", `cluster.name = "explicit"`)
+	m := newTestManager(t, fs)
+
+	loader := NewLoader(m)
+	loader.Path = "/somewhere/dcos.toml"
+
+	config, _, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := config.Get(keyClusterName); got != "explicit" {
+		t.Errorf("cluster.name = %#v, want %q", got, "explicit")
+	}
+}
+
+func TestLoaderLoadFromStdin(t *testing.T) {
+	m := newTestManager(t, afero.NewMemMapFs())
+	loader := NewLoader(m)
+	loader.Path = "-"
+	loader.Stdin = strings.NewReader(`cluster.name = "from-stdin"`)
+
+	config, _, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := config.Get(keyClusterName); got != "from-stdin" {
+		t.Errorf("cluster.name = %#v, want %q", got, "from-stdin")
+	}
+}
+
+func TestLoaderLoadByClusterName(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `cluster.name = "dev"`)
+	writeFile(t, fs, "/dcos/clusters/def/dcos.toml", `cluster.name = "prod"`)
+	m := newTestManager(t, fs)
+
+	loader := NewLoader(m)
+	loader.ClusterName = "prod"
+
+	config, _, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := config.Get(keyClusterName); got != "prod" {
+		t.Errorf("cluster.name = %#v, want %q", got, "prod")
+	}
+}
+
+func TestLoaderLoadFallsBackToLegacyPath(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/dcos.toml", `cluster.name = "legacy"`)
+	m := newTestManager(t, fs)
+
+	loader := NewLoader(m)
+	config, _, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := config.Get(keyClusterName); got != "legacy" {
+		t.Errorf("cluster.name = %#v, want %q", got, "legacy")
+	}
+}
+
+func TestLoaderLoadErrorsWhenNoClusterAndLegacySkipped(t *testing.T) {
+	m := newTestManager(t, afero.NewMemMapFs())
+
+	loader := NewLoader(m)
+	loader.SkipLegacy = true
+
+	if _, _, err := loader.Load(); err == nil {
+		t.Fatal("Load() = nil error, want an error")
+	}
+}
+
+func TestLoaderLoadSingleConfiguredCluster(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `cluster.name = "only"`)
+	m := newTestManager(t, fs)
+
+	config, _, err := NewLoader(m).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := config.Get(keyClusterName); got != "only" {
+		t.Errorf("cluster.name = %#v, want %q", got, "only")
+	}
+}
+
+func TestLoaderLoadMultipleClustersRequiresAttached(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `cluster.name = "dev"`)
+	writeFile(t, fs, "/dcos/clusters/def/dcos.toml", `cluster.name = "prod"`)
+	m := newTestManager(t, fs)
+
+	if _, _, err := NewLoader(m).Load(); err == nil {
+		t.Fatal("Load() = nil error, want an error (no cluster attached)")
+	}
+
+	writeFile(t, fs, "/dcos/clusters/def/attached", "")
+
+	config, _, err := NewLoader(m).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := config.Get(keyClusterName); got != "prod" {
+		t.Errorf("cluster.name = %#v, want %q", got, "prod")
+	}
+}
+
+type testLogger struct {
+	warnings []string
+}
+
+func (l *testLogger) Warn(args ...interface{}) {
+	if len(args) == 1 {
+		if s, ok := args[0].(string); ok {
+			l.warnings = append(l.warnings, s)
+			return
+		}
+	}
+}
+
+func TestLoaderDeprecationWarnings(t *testing.T) {
+	deprecatedKeys["core.reporting"] = "reporting is always on now"
+	defer delete(deprecatedKeys, "core.reporting")
+
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/dcos.toml", `core.reporting = true`)
+	m := newTestManager(t, fs)
+
+	logger := &testLogger{}
+	loader := NewLoader(m)
+	loader.Logger = logger
+
+	_, warnings, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "core.reporting") {
+		t.Errorf("warnings = %v, want one mentioning core.reporting", warnings)
+	}
+	if len(logger.warnings) != 1 {
+		t.Errorf("logger received %d warnings, want 1", len(logger.warnings))
+	}
+}
+
+func TestLoaderSkipDeprecated(t *testing.T) {
+	deprecatedKeys["core.reporting"] = "reporting is always on now"
+	defer delete(deprecatedKeys, "core.reporting")
+
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/dcos.toml", `core.reporting = true`)
+	m := newTestManager(t, fs)
+
+	loader := NewLoader(m)
+	loader.SkipDeprecated = true
+
+	_, warnings, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if warnings != nil {
+		t.Errorf("warnings = %v, want nil", warnings)
+	}
+}
+
+func TestNewLoaderLegacyPathUsesManagerJoin(t *testing.T) {
+	m := newTestManager(t, afero.NewMemMapFs())
+	loader := NewLoader(m)
+
+	want := m.join(m.dir, "dcos.toml")
+	if loader.LegacyPath != want {
+		t.Errorf("LegacyPath = %q, want %q", loader.LegacyPath, want)
+	}
+}
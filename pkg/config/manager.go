@@ -2,36 +2,57 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	iopath "path"
 	"path/filepath"
 	"strings"
 
 	"github.com/spf13/afero"
 )
 
+// envConfigBundle is the env var pointing at a tar- or zip-packaged, signed
+// "cluster bundle" to load clusters from read-only, e.g. in CI.
+const envConfigBundle = "DCOS_CONFIG_BUNDLE"
+
 // ManagerOpts are functional options for a Manager.
 type ManagerOpts struct {
 	// Fs is an abstraction for the filesystem. All filesystem operations
 	// for the manager should be done through it instead of the os package.
 	Fs afero.Fs
 
+	// ReadFS, when set, is used instead of Fs for every read done by the
+	// manager (discovering and loading cluster configs). Fs is still used
+	// for writes, so a Manager can read clusters from an embedded or
+	// archive-backed FS while still persisting new ones to disk.
+	ReadFS fs.FS
+
 	// EnvLookup is the function used to lookup environment variables.
 	// When not set it defaults to os.LookupEnv.
 	EnvLookup func(key string) (string, bool)
 
 	// Dir is the root directory for the config manager.
 	Dir string
+
+	// Environment selects the overlay directory merged on top of "_default"
+	// for each cluster. When empty, it falls back to the DCOS_ENV env var.
+	Environment string
 }
 
 // Manager is able to retrieve, create, and delete configs.
 type Manager struct {
 	fs        afero.Fs
+	readFS    fs.FS
 	envLookup func(key string) (string, bool)
 	dir       string
+	env       string
+	formats   []ConfigFormat
 }
 
-// NewManager creates a new config manager.
-func NewManager(opts ManagerOpts) *Manager {
+// NewManager creates a new config manager. It errors if DCOS_CONFIG_BUNDLE
+// is set and the bundle it points at can't be opened as an fs.FS.
+func NewManager(opts ManagerOpts) (*Manager, error) {
 	if opts.Fs == nil {
 		opts.Fs = afero.NewOsFs()
 	}
@@ -40,11 +61,56 @@ func NewManager(opts ManagerOpts) *Manager {
 		opts.EnvLookup = os.LookupEnv
 	}
 
+	if opts.ReadFS == nil {
+		if bundlePath, ok := opts.EnvLookup(envConfigBundle); ok {
+			bundleFS, err := openBundle(bundlePath)
+			if err != nil {
+				return nil, fmt.Errorf("config: opening bundle %s: %w", bundlePath, err)
+			}
+			opts.ReadFS = bundleFS
+		}
+	}
+
 	return &Manager{
 		fs:        opts.Fs,
+		readFS:    opts.ReadFS,
 		dir:       opts.Dir,
 		envLookup: opts.EnvLookup,
+		env:       opts.Environment,
+		formats:   defaultFormats(),
+	}, nil
+}
+
+// openBundle opens the cluster bundle at path as an fs.FS, picking ZipFS or
+// TarFS based on its extension.
+func openBundle(path string) (fs.FS, error) {
+	if filepath.Ext(path) == ".zip" {
+		return ZipFS(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
+	return TarFS(f)
+}
+
+// join joins path elements the way reads are addressed: with path.Join
+// (forward-slash, fs.FS-relative) when readFS is set, or filepath.Join
+// (OS-native, rooted at dir) otherwise.
+func (m *Manager) join(elem ...string) string {
+	if m.readFS != nil {
+		return iopath.Join(elem...)
+	}
+	return filepath.Join(elem...)
+}
+
+// RegisterFormat registers an additional ConfigFormat recognized by the
+// manager when discovering and loading cluster configs. It takes precedence
+// over previously registered formats sharing one of its extensions.
+func (m *Manager) RegisterFormat(format ConfigFormat) {
+	m.formats = append([]ConfigFormat{format}, m.formats...)
 }
 
 // Current retrieves the current config.
@@ -54,38 +120,20 @@ func NewManager(opts ManagerOpts) *Manager {
 // - DCOS_CLUSTER is defined and is the name/ID of a configured cluster.
 // - An attached file exists alongside a configured cluster, OR there is a single configured cluster.
 // - A legacy config file exists (at DCOS_DIR/dcos.toml).
+//
+// Current is kept as a thin, environment-variable-driven wrapper around
+// Loader for backward compatibility. Callers that need flag-driven
+// resolution (e.g. --config, --cluster) should use NewLoader directly.
 func (m *Manager) Current() (*Config, error) {
+	loader := NewLoader(m)
 	if configPath, ok := m.envLookup("DCOS_CONFIG"); ok {
-		config := m.newConfig()
-		return config, config.LoadPath(configPath)
+		loader.Path = configPath
 	}
-
 	if configName, ok := m.envLookup("DCOS_CLUSTER"); ok {
-		return m.Find(configName, true)
-	}
-
-	configs := m.All()
-	switch len(configs) {
-	case 0:
-		config := m.newConfig()
-		return config, config.LoadPath(filepath.Join(m.dir, "dcos.toml"))
-	case 1:
-		return configs[0], nil
-	default:
-		var currentConfig *Config
-		for _, config := range configs {
-			if config.Attached() {
-				if currentConfig != nil {
-					return nil, errors.New("multiple clusters are attached")
-				}
-				currentConfig = config
-			}
-		}
-		if currentConfig == nil {
-			return nil, errors.New("no cluster is attached")
-		}
-		return currentConfig, nil
+		loader.ClusterName = configName
 	}
+	config, _, err := loader.Load()
+	return config, err
 }
 
 // Find finds a config by cluster name or ID, `strict` indicates
@@ -117,32 +165,117 @@ func (m *Manager) Find(name string, strict bool) (*Config, error) {
 
 // All retrieves all configs.
 func (m *Manager) All() (configs []*Config) {
-	configsDir, err := m.fs.Open(filepath.Join(m.dir, "clusters"))
+	clusterDirs, err := m.clusterDirs()
 	if err != nil {
 		return
 	}
-	defer configsDir.Close()
 
-	configsDirInfo, err := configsDir.Readdir(-1)
+	for _, clusterDir := range clusterDirs {
+		configPath, err := m.baseConfigPath(clusterDir)
+		if err != nil {
+			continue
+		}
+		config := m.newConfig()
+		if err := config.LoadPath(configPath); err == nil {
+			m.applyOverlays(config, clusterDir)
+			configs = append(configs, config)
+		}
+	}
+	return
+}
+
+// clusterDirs lists the immediate subdirectories of "clusters", each one a
+// candidate cluster. When readFS is set it walks it with fs.WalkDir,
+// stopping at depth 1; otherwise it lists directly through afero.
+func (m *Manager) clusterDirs() ([]string, error) {
+	root := m.join(m.dir, "clusters")
+
+	if m.readFS == nil {
+		configsDir, err := m.fs.Open(root)
+		if err != nil {
+			return nil, err
+		}
+		defer configsDir.Close()
+
+		infos, err := configsDir.Readdir(-1)
+		if err != nil {
+			return nil, err
+		}
+
+		var dirs []string
+		for _, info := range infos {
+			if info.IsDir() {
+				dirs = append(dirs, filepath.Join(root, info.Name()))
+			}
+		}
+		return dirs, nil
+	}
+
+	root = m.join("clusters")
+	var dirs []string
+	err := fs.WalkDir(m.readFS, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if d.IsDir() {
+			dirs = append(dirs, p)
+			return fs.SkipDir
+		}
+		return nil
+	})
 	if err != nil {
-		return
+		return nil, err
 	}
+	return dirs, nil
+}
 
-	for _, configDirInfo := range configsDirInfo {
-		if configDirInfo.IsDir() {
-			config := m.newConfig()
-			configPath := filepath.Join(configsDir.Name(), configDirInfo.Name(), "dcos.toml")
-			if err := config.LoadPath(configPath); err == nil {
-				configs = append(configs, config)
+// baseConfigPath returns the path of the single "dcos.<ext>" file within
+// clusterDir, among the extensions recognized by the manager's registered
+// formats. It errors if none or more than one is present.
+func (m *Manager) baseConfigPath(clusterDir string) (string, error) {
+	var matches []string
+	seen := make(map[string]bool)
+	for _, format := range m.formats {
+		for _, e := range format.Extensions() {
+			path := m.join(clusterDir, "dcos."+e)
+			if seen[path] {
+				continue
+			}
+			if info, err := m.statPath(path); err == nil && !info.IsDir() {
+				matches = append(matches, path)
+				seen[path] = true
 			}
 		}
 	}
-	return
+
+	switch len(matches) {
+	case 0:
+		return "", os.ErrNotExist
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("config: ambiguous config for cluster %q: %s",
+			iopath.Base(clusterDir), strings.Join(matches, ", "))
+	}
+}
+
+// statPath stats path through readFS when set, or through the afero Fs
+// otherwise.
+func (m *Manager) statPath(path string) (os.FileInfo, error) {
+	if m.readFS != nil {
+		return fs.Stat(m.readFS, path)
+	}
+	return m.fs.Stat(path)
 }
 
 func (m *Manager) newConfig() *Config {
 	return New(Opts{
 		EnvLookup: m.envLookup,
 		Fs:        m.fs,
+		ReadFS:    m.readFS,
+		Formats:   m.formats,
 	})
-}
\ No newline at end of file
+}
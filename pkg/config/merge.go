@@ -0,0 +1,97 @@
+package config
+
+import "strings"
+
+// Strategy controls how a map is merged onto another during overlay
+// loading.
+type Strategy string
+
+const (
+	// StrategyDeep recursively merges maps by key; scalars and arrays from
+	// src replace the corresponding value in dst. It is the default.
+	StrategyDeep Strategy = "deep"
+
+	// StrategyShallow merges src's top-level keys onto dst without
+	// recursing into nested tables: each key present in src replaces dst's
+	// value wholesale, map or not.
+	StrategyShallow Strategy = "shallow"
+
+	// StrategyNone discards dst entirely in favor of src.
+	StrategyNone Strategy = "none"
+)
+
+// mergeDirectiveKey is the key a config table can set to control how it (or
+// one of its nested tables) is merged onto a less-specific layer, e.g.
+// `_merge = "none"` within the `[core]` table forces the entire "core" table
+// to be replaced rather than deep-merged.
+const mergeDirectiveKey = "_merge"
+
+// Merge merges src onto dst following strategy, which applies to src's
+// top-level table. A "_merge" key within src (or, recursively, within any of
+// its nested tables) overrides strategy for that table and is inherited by
+// its own nested tables unless they set their own. Merge returns a new map;
+// dst and src are not mutated.
+func Merge(dst, src map[string]interface{}, strategy Strategy) map[string]interface{} {
+	if s, ok := mergeStrategy(src); ok {
+		strategy = s
+	}
+
+	var out map[string]interface{}
+	switch strategy {
+	case StrategyNone:
+		out = cloneMap(src)
+
+	case StrategyShallow:
+		out = cloneMap(dst)
+		for k, v := range src {
+			if k == mergeDirectiveKey {
+				continue
+			}
+			out[k] = v
+		}
+
+	default: // StrategyDeep
+		out = cloneMap(dst)
+		for k, v := range src {
+			if k == mergeDirectiveKey {
+				continue
+			}
+			if srcMap, ok := v.(map[string]interface{}); ok {
+				if dstMap, ok := out[k].(map[string]interface{}); ok {
+					out[k] = Merge(dstMap, srcMap, strategy)
+					continue
+				}
+			}
+			out[k] = v
+		}
+	}
+
+	delete(out, mergeDirectiveKey)
+	return out
+}
+
+// mergeStrategy reads the "_merge" directive from m, if present and valid.
+func mergeStrategy(m map[string]interface{}) (Strategy, bool) {
+	v, ok := m[mergeDirectiveKey]
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	switch strategy := Strategy(strings.ToLower(s)); strategy {
+	case StrategyNone, StrategyShallow, StrategyDeep:
+		return strategy, true
+	default:
+		return "", false
+	}
+}
+
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
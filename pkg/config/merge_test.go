@@ -0,0 +1,173 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		dst      map[string]interface{}
+		src      map[string]interface{}
+		strategy Strategy
+		want     map[string]interface{}
+	}{
+		{
+			name: "deep merge recurses into nested tables",
+			dst: map[string]interface{}{
+				"core": map[string]interface{}{"dcos_url": "https://dev", "timeout": 5},
+			},
+			src: map[string]interface{}{
+				"core": map[string]interface{}{"dcos_url": "https://prod"},
+			},
+			strategy: StrategyDeep,
+			want: map[string]interface{}{
+				"core": map[string]interface{}{"dcos_url": "https://prod", "timeout": 5},
+			},
+		},
+		{
+			name: "scalar in src replaces scalar in dst",
+			dst:  map[string]interface{}{"core": map[string]interface{}{"timeout": 5}},
+			src:  map[string]interface{}{"core": map[string]interface{}{"timeout": 30}},
+			want: map[string]interface{}{"core": map[string]interface{}{"timeout": 30}},
+		},
+		{
+			name: "array of tables in src replaces the one in dst wholesale, not element-wise",
+			dst: map[string]interface{}{
+				"servers": []interface{}{
+					map[string]interface{}{"name": "dev-1"},
+					map[string]interface{}{"name": "dev-2"},
+				},
+			},
+			src: map[string]interface{}{
+				"servers": []interface{}{
+					map[string]interface{}{"name": "prod-1"},
+				},
+			},
+			want: map[string]interface{}{
+				"servers": []interface{}{
+					map[string]interface{}{"name": "prod-1"},
+				},
+			},
+		},
+		{
+			name:     "shallow strategy replaces whole top-level tables instead of recursing",
+			strategy: StrategyShallow,
+			dst: map[string]interface{}{
+				"core": map[string]interface{}{"dcos_url": "https://dev", "timeout": 5},
+			},
+			src: map[string]interface{}{
+				"core": map[string]interface{}{"dcos_url": "https://prod"},
+			},
+			want: map[string]interface{}{
+				"core": map[string]interface{}{"dcos_url": "https://prod"},
+			},
+		},
+		{
+			name:     "none strategy discards dst entirely",
+			strategy: StrategyDeep,
+			dst: map[string]interface{}{
+				"core":    map[string]interface{}{"timeout": 5},
+				"cluster": map[string]interface{}{"name": "dev"},
+			},
+			src: map[string]interface{}{
+				"_merge": "none",
+				"core":   map[string]interface{}{"dcos_url": "https://prod"},
+			},
+			want: map[string]interface{}{
+				"core": map[string]interface{}{"dcos_url": "https://prod"},
+			},
+		},
+		{
+			name:     "a nested _merge directive overrides the strategy for its own table only",
+			strategy: StrategyDeep,
+			dst: map[string]interface{}{
+				"core":    map[string]interface{}{"timeout": 5, "dcos_url": "https://dev"},
+				"cluster": map[string]interface{}{"name": "dev", "id": "abc"},
+			},
+			src: map[string]interface{}{
+				"core":    map[string]interface{}{"_merge": "none", "dcos_url": "https://prod"},
+				"cluster": map[string]interface{}{"name": "prod"},
+			},
+			want: map[string]interface{}{
+				"core":    map[string]interface{}{"dcos_url": "https://prod"},
+				"cluster": map[string]interface{}{"name": "prod", "id": "abc"},
+			},
+		},
+		{
+			name:     "deep strategy is inherited through multiple nesting levels without an explicit directive",
+			strategy: StrategyDeep,
+			dst: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"mid": map[string]interface{}{
+						"inner": map[string]interface{}{"a": 1, "b": 2},
+					},
+				},
+			},
+			src: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"mid": map[string]interface{}{
+						"inner": map[string]interface{}{"b": 3},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"mid": map[string]interface{}{
+						"inner": map[string]interface{}{"a": 1, "b": 3},
+					},
+				},
+			},
+		},
+		{
+			name:     "a _merge override deep in the tree doesn't leak back up to sibling tables",
+			strategy: StrategyDeep,
+			dst: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"mid":   map[string]interface{}{"inner": map[string]interface{}{"a": 1, "b": 2}},
+					"other": map[string]interface{}{"inner": map[string]interface{}{"a": 1, "b": 2}},
+				},
+			},
+			src: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"mid":   map[string]interface{}{"_merge": "shallow", "inner": map[string]interface{}{"b": 3}},
+					"other": map[string]interface{}{"inner": map[string]interface{}{"b": 3}},
+				},
+			},
+			want: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"mid":   map[string]interface{}{"inner": map[string]interface{}{"b": 3}},
+					"other": map[string]interface{}{"inner": map[string]interface{}{"a": 1, "b": 3}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := tt.strategy
+			if strategy == "" {
+				strategy = StrategyDeep
+			}
+			got := Merge(tt.dst, tt.src, strategy)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDoesNotMutateInputs(t *testing.T) {
+	dst := map[string]interface{}{"core": map[string]interface{}{"timeout": 5}}
+	src := map[string]interface{}{"core": map[string]interface{}{"timeout": 30}}
+
+	Merge(dst, src, StrategyDeep)
+
+	if dst["core"].(map[string]interface{})["timeout"] != 5 {
+		t.Errorf("Merge mutated dst: %#v", dst)
+	}
+	if src["core"].(map[string]interface{})["timeout"] != 30 {
+		t.Errorf("Merge mutated src: %#v", src)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml"
+)
+
+// envEnvironment is the environment variable used to select which overlay
+// directory is applied on top of "_default".
+const envEnvironment = "DCOS_ENV"
+
+// environment returns the environment overlay to apply, sourced from
+// ManagerOpts.Environment if set, otherwise from DCOS_ENV.
+func (m *Manager) environment() string {
+	if m.env != "" {
+		return m.env
+	}
+	if env, ok := m.envLookup(envEnvironment); ok {
+		return env
+	}
+	return ""
+}
+
+// overlayDirs returns the ordered list of overlay directories for a cluster,
+// from least-specific to most-specific.
+func (m *Manager) overlayDirs(clusterDir string) []string {
+	dirs := []string{m.join(clusterDir, "_default")}
+	if env := m.environment(); env != "" {
+		dirs = append(dirs, m.join(clusterDir, env))
+	}
+	return dirs
+}
+
+// layerFilenames returns the "dcos.<ext>" filenames looked up within an
+// overlay directory, one per distinct extension recognized by the manager's
+// registered formats (two formats registered for the same extension yield a
+// single filename, so its file isn't merged twice).
+func (m *Manager) layerFilenames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, format := range m.formats {
+		for _, e := range format.Extensions() {
+			name := "dcos." + e
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// applyOverlays merges the "_default" and environment overlay directories for
+// a cluster on top of its base config, from least to most specific.
+func (m *Manager) applyOverlays(config *Config, clusterDir string) {
+	for _, dir := range m.overlayDirs(clusterDir) {
+		m.applyOverlayDir(config, dir)
+	}
+}
+
+// applyOverlayDir merges every layer file found directly within dir onto
+// config. Errors merging an individual overlay are ignored so that a
+// malformed environment file doesn't prevent the cluster from loading at all.
+func (m *Manager) applyOverlayDir(config *Config, dir string) {
+	info, err := m.statPath(dir)
+	if err != nil || !info.IsDir() {
+		return
+	}
+	for _, filename := range m.layerFilenames() {
+		path := m.join(dir, filename)
+		if _, err := m.statPath(path); err != nil {
+			continue
+		}
+		config.mergeOverlay(path)
+	}
+}
+
+// mergeOverlay loads the config file at path and merges it on top of c,
+// recording it as a layer. The merge defaults to Strategy deep, but honors
+// any "_merge" directive set within path. See Merge for the precedence
+// rules.
+func (c *Config) mergeOverlay(path string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	format := formatForExt(c.formats, ext(path))
+	if format == nil {
+		return fmt.Errorf("config: unsupported file extension %q", filepath.Ext(path))
+	}
+
+	data, err := c.readFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := format.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	merged := Merge(c.tree.ToMap(), decoded, StrategyDeep)
+	tree, err := toml.TreeFromMap(merged)
+	if err != nil {
+		return err
+	}
+
+	c.tree = tree
+	c.layers = append(c.layers, path)
+	return nil
+}
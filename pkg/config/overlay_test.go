@@ -0,0 +1,160 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func writeFile(t *testing.T, fs afero.Fs, path, contents string) {
+	t.Helper()
+	if err := afero.WriteFile(fs, path, []byte(contents), 0600); err != nil {
+		t.Fatalf("writeFile(%s): %v", path, err)
+	}
+}
+
+func TestManagerAllAppliesOverlays(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		wantTimeout interface{}
+		wantRetries interface{}
+	}{
+		{
+			name:        "no environment set, only _default is applied",
+			wantTimeout: int64(5),
+			wantRetries: int64(3),
+		},
+		{
+			name:        "environment overlay overrides _default and the base config",
+			environment: "dev",
+			wantTimeout: int64(30),
+			wantRetries: int64(10),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `
+cluster.name = "test"
+core.timeout = 5
+`)
+			writeFile(t, fs, "/dcos/clusters/abc/_default/dcos.toml", `
+core.retries = 3
+`)
+			writeFile(t, fs, "/dcos/clusters/abc/dev/dcos.toml", `
+core.timeout = 30
+core.retries = 10
+`)
+
+			m, err := NewManager(ManagerOpts{
+				Fs:          fs,
+				Dir:         "/dcos",
+				Environment: tt.environment,
+				EnvLookup:   func(string) (string, bool) { return "", false },
+			})
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			configs := m.All()
+			if len(configs) != 1 {
+				t.Fatalf("All() returned %d configs, want 1", len(configs))
+			}
+			config := configs[0]
+
+			if got := config.Get("core.timeout"); got != tt.wantTimeout {
+				t.Errorf("core.timeout = %#v, want %#v", got, tt.wantTimeout)
+			}
+			if got := config.Get("core.retries"); got != tt.wantRetries {
+				t.Errorf("core.retries = %#v, want %#v", got, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestManagerAllRecordsLayersInOrder(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `cluster.name = "test"`)
+	writeFile(t, fs, "/dcos/clusters/abc/_default/dcos.toml", `core.retries = 3`)
+	writeFile(t, fs, "/dcos/clusters/abc/dev/dcos.toml", `core.timeout = 30`)
+
+	m, err := NewManager(ManagerOpts{
+		Fs:          fs,
+		Dir:         "/dcos",
+		Environment: "dev",
+		EnvLookup:   func(string) (string, bool) { return "", false },
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	configs := m.All()
+	if len(configs) != 1 {
+		t.Fatalf("All() returned %d configs, want 1", len(configs))
+	}
+
+	want := []string{
+		"/dcos/clusters/abc/dcos.toml",
+		"/dcos/clusters/abc/_default/dcos.toml",
+		"/dcos/clusters/abc/dev/dcos.toml",
+	}
+	got := configs[0].Layers()
+	if len(got) != len(want) {
+		t.Fatalf("Layers() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Layers()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestManagerAllSkipsOverlaysWhenEnvironmentDirMissing(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFile(t, fs, "/dcos/clusters/abc/dcos.toml", `
+cluster.name = "test"
+core.timeout = 5
+`)
+
+	m, err := NewManager(ManagerOpts{
+		Fs:          fs,
+		Dir:         "/dcos",
+		Environment: "prod",
+		EnvLookup:   func(string) (string, bool) { return "", false },
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	configs := m.All()
+	if len(configs) != 1 {
+		t.Fatalf("All() returned %d configs, want 1", len(configs))
+	}
+	if got := configs[0].Get("core.timeout"); got != int64(5) {
+		t.Errorf("core.timeout = %#v, want 5", got)
+	}
+	if got := configs[0].Layers(); len(got) != 1 {
+		t.Errorf("Layers() = %v, want a single base layer", got)
+	}
+}
+
+func TestManagerEnvironmentFallsBackToEnvLookup(t *testing.T) {
+	m, err := NewManager(ManagerOpts{
+		Fs: afero.NewMemMapFs(),
+		EnvLookup: func(key string) (string, bool) {
+			if key == envEnvironment {
+				return "staging", true
+			}
+			return "", false
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	if got := m.environment(); got != "staging" {
+		t.Errorf("environment() = %q, want %q", got, "staging")
+	}
+}
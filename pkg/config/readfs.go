@@ -0,0 +1,206 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	iopath "path"
+	"sort"
+	"time"
+)
+
+// DirFS returns an fs.FS rooted at dir on the host filesystem, suitable for
+// ManagerOpts.ReadFS. It exists so callers depend on config's read-path
+// story rather than on os.DirFS directly.
+func DirFS(dir string) (fs.FS, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("config: %s is not a directory", dir)
+	}
+	return os.DirFS(dir), nil
+}
+
+// EmbedFS adapts an embed.FS rooted at prefix into an fs.FS usable as
+// ManagerOpts.ReadFS, so a set of default cluster configs can be baked into
+// the dcos binary itself.
+func EmbedFS(fsys embed.FS, prefix string) (fs.FS, error) {
+	return fs.Sub(fsys, prefix)
+}
+
+// ZipFS returns a read-only fs.FS backed by the zip archive at path.
+func ZipFS(path string) (fs.FS, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TarFS returns a read-only fs.FS backed by the tar stream read from r. It is
+// used to load a "cluster bundle" (clusters/<id>/dcos.toml plus a CA bundle
+// and an attached file) shipped as a single tar file, e.g. via the
+// DCOS_CONFIG_BUNDLE env var.
+func TarFS(r io.Reader) (fs.FS, error) {
+	files := make(map[string][]byte)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[iopath.Clean(hdr.Name)] = data
+	}
+	return newMemFS(files), nil
+}
+
+// memFS is a minimal, read-only, in-memory fs.FS built from a flat set of
+// file paths. It backs TarFS, since archive/tar doesn't expose one directly.
+type memFS struct {
+	files map[string][]byte
+	dirs  map[string]map[string]bool
+}
+
+func newMemFS(files map[string][]byte) *memFS {
+	fsys := &memFS{files: files, dirs: map[string]map[string]bool{".": {}}}
+	for name := range files {
+		child := name
+		dir := iopath.Dir(child)
+		for {
+			if fsys.dirs[dir] == nil {
+				fsys.dirs[dir] = map[string]bool{}
+			}
+			fsys.dirs[dir][iopath.Base(child)] = true
+			if dir == "." {
+				break
+			}
+			child = dir
+			dir = iopath.Dir(dir)
+		}
+	}
+	return fsys
+}
+
+func (fsys *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if data, ok := fsys.files[name]; ok {
+		return &memFile{
+			name:   iopath.Base(name),
+			size:   int64(len(data)),
+			Reader: bytes.NewReader(data),
+		}, nil
+	}
+
+	if children, ok := fsys.dirs[name]; ok {
+		entries := make([]fs.DirEntry, 0, len(children))
+		for child := range children {
+			_, isDir := fsys.dirs[iopath.Join(name, child)]
+			entries = append(entries, memDirEntry{name: child, isDir: isDir})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		return &memDir{name: iopath.Base(name), entries: entries}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// memFile is an fs.File backed by an in-memory byte slice.
+type memFile struct {
+	*bytes.Reader
+	name string
+	size int64
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return memFileInfo{f.name, f.size, false}, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an fs.ReadDirFile listing a directory's immediate children.
+type memDir struct {
+	name    string
+	entries []fs.DirEntry
+	read    int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return memFileInfo{d.name, 0, true}, nil }
+func (d *memDir) Close() error               { return nil }
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fmt.Errorf("is a directory")}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries[d.read:]
+		d.read = len(d.entries)
+		return entries, nil
+	}
+
+	remaining := len(d.entries) - d.read
+	if remaining == 0 {
+		return nil, io.EOF
+	}
+	if n > remaining {
+		n = remaining
+	}
+	entries := d.entries[d.read : d.read+n]
+	d.read += n
+	return entries, nil
+}
+
+// memFileInfo implements fs.FileInfo for memFS entries.
+type memFileInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (fi memFileInfo) Name() string { return fi.name }
+func (fi memFileInfo) Size() int64  { return fi.size }
+func (fi memFileInfo) Mode() fs.FileMode {
+	if fi.isDir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+func (fi memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memFileInfo) IsDir() bool        { return fi.isDir }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+// memDirEntry implements fs.DirEntry for memFS entries.
+type memDirEntry struct {
+	name  string
+	isDir bool
+}
+
+func (e memDirEntry) Name() string { return e.name }
+func (e memDirEntry) IsDir() bool  { return e.isDir }
+func (e memDirEntry) Type() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+func (e memDirEntry) Info() (fs.FileInfo, error) {
+	return memFileInfo{e.name, 0, e.isDir}, nil
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func buildTar(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, contents := range files {
+		hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(contents))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader(%s): %v", name, err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatalf("Write(%s): %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return &buf
+}
+
+func TestTarFSReadsFilesAndListsDirectories(t *testing.T) {
+	buf := buildTar(t, map[string]string{
+		"clusters/abc/dcos.toml": `cluster.name = "bundled"`,
+		"clusters/abc/attached":  "",
+	})
+
+	fsys, err := TarFS(buf)
+	if err != nil {
+		t.Fatalf("TarFS: %v", err)
+	}
+
+	data, err := fs.ReadFile(fsys, "clusters/abc/dcos.toml")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != `cluster.name = "bundled"` {
+		t.Errorf("ReadFile = %q", data)
+	}
+
+	entries, err := fs.ReadDir(fsys, "clusters")
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "abc" || !entries[0].IsDir() {
+		t.Errorf("ReadDir(clusters) = %v, want a single dir entry %q", entries, "abc")
+	}
+
+	if _, err := fs.Stat(fsys, "clusters/abc/attached"); err != nil {
+		t.Errorf("Stat(attached): %v", err)
+	}
+
+	if _, err := fs.Stat(fsys, "clusters/missing"); err == nil {
+		t.Error("Stat(missing) = nil error, want fs.ErrNotExist")
+	}
+}
+
+func TestTarFSWalkDirFindsClusterDirs(t *testing.T) {
+	buf := buildTar(t, map[string]string{
+		"clusters/abc/dcos.toml": `cluster.name = "one"`,
+		"clusters/def/dcos.toml": `cluster.name = "two"`,
+	})
+
+	fsys, err := TarFS(buf)
+	if err != nil {
+		t.Fatalf("TarFS: %v", err)
+	}
+
+	var dirs []string
+	err = fs.WalkDir(fsys, "clusters", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "clusters" && d.IsDir() {
+			dirs = append(dirs, p)
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir: %v", err)
+	}
+	if len(dirs) != 2 {
+		t.Fatalf("WalkDir found %v, want 2 cluster dirs", dirs)
+	}
+}
+
+func TestZipFSReadsFiles(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "bundle.zip")
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("clusters/abc/dcos.toml")
+	if err != nil {
+		t.Fatalf("zip Create: %v", err)
+	}
+	if _, err := w.Write([]byte(`cluster.name = "zipped"`)); err != nil {
+		t.Fatalf("zip Write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fsys, err := ZipFS(archivePath)
+	if err != nil {
+		t.Fatalf("ZipFS: %v", err)
+	}
+
+	data, err := fs.ReadFile(fsys, "clusters/abc/dcos.toml")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != `cluster.name = "zipped"` {
+		t.Errorf("ReadFile = %q", data)
+	}
+}
+
+func TestDirFSReadsFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "clusters", "abc"), 0700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "clusters", "abc", "dcos.toml"), []byte(`cluster.name = "dirfs"`), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	fsys, err := DirFS(dir)
+	if err != nil {
+		t.Fatalf("DirFS: %v", err)
+	}
+
+	data, err := fs.ReadFile(fsys, "clusters/abc/dcos.toml")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != `cluster.name = "dirfs"` {
+		t.Errorf("ReadFile = %q", data)
+	}
+}
+
+func TestDirFSErrorsOnNonDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "not-a-dir")
+	if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := DirFS(path); err == nil {
+		t.Error("DirFS(file) = nil error, want an error")
+	}
+}
+
+func TestManagerAllFromBundledReadFS(t *testing.T) {
+	buf := buildTar(t, map[string]string{
+		"clusters/abc/dcos.toml":          `cluster.name = "bundled"`,
+		"clusters/abc/_default/dcos.toml": `core.retries = 3`,
+	})
+
+	fsys, err := TarFS(buf)
+	if err != nil {
+		t.Fatalf("TarFS: %v", err)
+	}
+
+	m, err := NewManager(ManagerOpts{
+		Fs:        afero.NewMemMapFs(),
+		ReadFS:    fsys,
+		EnvLookup: func(string) (string, bool) { return "", false },
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	configs := m.All()
+	if len(configs) != 1 {
+		t.Fatalf("All() returned %d configs, want 1", len(configs))
+	}
+	if got := configs[0].Get(keyClusterName); got != "bundled" {
+		t.Errorf("cluster.name = %#v, want %q", got, "bundled")
+	}
+	if got := configs[0].Get("core.retries"); got != int64(3) {
+		t.Errorf("core.retries = %#v, want 3", got)
+	}
+}
+
+func TestNewManagerSurfacesBundleOpenErrors(t *testing.T) {
+	_, err := NewManager(ManagerOpts{
+		Fs: afero.NewMemMapFs(),
+		EnvLookup: func(key string) (string, bool) {
+			if key == envConfigBundle {
+				return "/does/not/exist.tar", true
+			}
+			return "", false
+		},
+	})
+	if err == nil {
+		t.Fatal("NewManager() = nil error, want an error for an unreadable bundle")
+	}
+}